@@ -0,0 +1,717 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bft
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SmartBFT-Go/consensus/pkg/api"
+	"github.com/SmartBFT-Go/consensus/smartbftprotos"
+)
+
+// timestampRingSize bounds how many recent (view, seq, timestamp) samples are kept per node;
+// MedianTimestamp only ever consumes the latest one, older entries exist for diagnostics.
+const timestampRingSize = 3
+
+// minAdaptiveHeartbeatTimeout floors the adaptively-derived timeout so that a handful of
+// suspiciously fast RTT samples (or jitter collapsing rttvar towards zero) can never shrink it to
+// something that would trip on ordinary scheduling noise.
+const minAdaptiveHeartbeatTimeout = 100 * time.Millisecond
+
+// pendingSendHistory bounds how many in-flight (nonce -> send time) entries are kept for RTT
+// matching; entries older than this many windows are pruned as stale and unanswered.
+const pendingSendHistory = 4
+
+// Role denotes whether a node currently emits heartbeats (Leader) or consumes them (Follower).
+type Role int
+
+const (
+	Follower Role = iota
+	Leader
+)
+
+// ViewSequence is the subset of a view's state the heartbeat monitor needs to decide whether to
+// emit a heartbeat and whether to accept one.
+type ViewSequence struct {
+	ViewActive  bool
+	ProposalSeq uint64
+}
+
+// HeartbeatTimeoutHandler reacts to a leader having gone silent for too long, or to the leader
+// itself losing touch with the rest of the cluster.
+type HeartbeatTimeoutHandler interface {
+	OnHeartbeatTimeout(view uint64, leaderID uint64)
+	// OnQuorumLoss is invoked on the leader when fewer than a quorum of nodes, counting the leader
+	// itself, have acknowledged its heartbeats for LeaderHeartbeatCount consecutive windows, so it can step
+	// down and trigger a view change instead of waiting for followers to time it out themselves.
+	OnQuorumLoss(view uint64)
+}
+
+// Comm sends heartbeat related messages to the rest of the cluster.
+type Comm interface {
+	BroadcastConsensus(m *smartbftprotos.Message)
+	SendConsensus(targetID uint64, m *smartbftprotos.Message)
+}
+
+// MembershipResolver tells the heartbeat monitor whether this node currently has a vote in a
+// given view, so a node reconfigured out to a non-voting "listener" role stops broadcasting
+// heartbeats even while it still otherwise believes itself to be the leader.
+type MembershipResolver interface {
+	IsVoter(nodeID uint64, view uint64) bool
+}
+
+// HeartbeatMonitor both emits heartbeats when this node is a leader, and consumes the heartbeats
+// of the current leader when this node is a follower, invoking HeartbeatTimeoutHandler when they
+// stop arriving.
+type HeartbeatMonitor struct {
+	scheduler        <-chan time.Time
+	log              api.Logger
+	heartbeatTimeout time.Duration
+	heartbeatCount   uint64
+	increment        time.Duration
+	comm             Comm
+	handler          HeartbeatTimeoutHandler
+	viewSequence     *atomic.Value
+	selfID           uint64
+	signer           api.Signer
+	verifier         api.Verifier
+	quorumSize       uint64
+	weights          map[uint64]uint64
+	adaptive         bool
+	resolver         MembershipResolver
+	precision        time.Duration
+	msgDelay         time.Duration
+
+	stopChan  chan struct{}
+	closeOnce sync.Once
+
+	mutex               sync.Mutex
+	role                Role
+	view                uint64
+	leaderID            uint64
+	lastSent            time.Time                    // leader side: last time a heartbeat was actually sent
+	toCount             uint64                       // follower side: ticks elapsed since the last accepted heartbeat
+	timedOut            bool                         // follower side: whether OnHeartbeatTimeout already fired for this view
+	nonce               uint64                       // leader side: own monotonically increasing heartbeat nonce
+	lastNonce           uint64                       // follower side: highest nonce accepted from the current leader
+	ackedFollowers      map[uint64]bool              // leader side: followers that acked a heartbeat in the current window
+	missedQuorumWindows uint64                       // leader side: consecutive windows that fell short of quorum
+	quorumLossSignaled  bool                         // leader side: whether OnQuorumLoss already fired for this view
+	timestamps          map[uint64][]timestampSample // nodeID -> ring buffer of recently observed samples
+	pendingSends        map[uint64]time.Time         // leader side: nonce -> real time it was broadcast, for RTT matching
+	rtt                 map[uint64]*rttEstimate      // leader side: nodeID -> EWMA round-trip estimate
+	isVoter             bool                         // leader side: cached result of resolver.IsVoter(selfID, view)
+}
+
+// rttEstimate is a TCP-style smoothed round-trip estimate for a single peer: srtt is the EWMA of
+// observed samples and rttvar is the EWMA of their mean deviation from srtt.
+type rttEstimate struct {
+	srtt   time.Duration
+	rttvar time.Duration
+}
+
+// update folds a new RTT sample into the estimate using the same weights TCP uses for its
+// retransmission timer (RFC 6298): srtt = 7/8*srtt + 1/8*sample, rttvar = 3/4*rttvar + 1/4*|srtt-sample|.
+func (e *rttEstimate) update(sample time.Duration) {
+	if e.srtt == 0 {
+		e.srtt = sample
+		e.rttvar = sample / 2
+		return
+	}
+	delta := e.srtt - sample
+	if delta < 0 {
+		delta = -delta
+	}
+	e.rttvar = e.rttvar*3/4 + delta/4
+	e.srtt = e.srtt*7/8 + sample/8
+}
+
+// timeout is the effective heartbeat timeout this estimate implies, per the adaptive formula.
+func (e *rttEstimate) timeout() time.Duration {
+	return e.srtt + 4*e.rttvar
+}
+
+// RTTStats is a point-in-time snapshot of the RTT estimate tracked for a single follower.
+type RTTStats struct {
+	SRTT   time.Duration
+	RTTVar time.Duration
+}
+
+// HeartbeatStats is a point-in-time snapshot of HeartbeatMonitor's adaptive state, exposed for
+// observability.
+type HeartbeatStats struct {
+	// Adaptive reports whether AdaptiveHeartbeat is enabled for this monitor.
+	Adaptive bool
+	// EffectiveTimeout is the timeout currently in effect: the configured LeaderHeartbeatTimeout
+	// when Adaptive is false or no RTT samples exist yet, otherwise the RTT-derived timeout.
+	EffectiveTimeout time.Duration
+	// Interval is the leader-side broadcast interval currently in effect. It is recomputed as
+	// EffectiveTimeout / LeaderHeartbeatCount at each broadcast, so after a new RTT sample it can
+	// differ from EffectiveTimeout / LeaderHeartbeatCount until the next broadcast.
+	Interval time.Duration
+	// RTT holds the current per-follower round-trip estimate, keyed by node ID. Empty until this
+	// node has observed a response while leading; estimates are kept across role changes.
+	RTT map[uint64]RTTStats
+}
+
+// timestampSample is one (view, seq, timestamp) observation from a single node, used to compute a
+// BFT-Time median.
+type timestampSample struct {
+	view      uint64
+	seq       uint64
+	timestamp time.Time
+}
+
+// NewHeartbeatMonitor creates a HeartbeatMonitor and starts its background goroutine. scheduler is
+// expected to deliver a tick roughly every heartbeatTimeout/heartbeatCount.
+func NewHeartbeatMonitor(
+	scheduler <-chan time.Time,
+	log api.Logger,
+	heartbeatTimeout time.Duration,
+	heartbeatCount uint64,
+	comm Comm,
+	handler HeartbeatTimeoutHandler,
+	viewSequence *atomic.Value,
+	selfID uint64,
+	signer api.Signer,
+	verifier api.Verifier,
+	quorumSize uint64,
+	weights map[uint64]uint64,
+	adaptive bool,
+	resolver MembershipResolver,
+	precision time.Duration,
+	msgDelay time.Duration,
+) *HeartbeatMonitor {
+	hm := &HeartbeatMonitor{
+		scheduler:        scheduler,
+		log:              log,
+		heartbeatTimeout: heartbeatTimeout,
+		heartbeatCount:   heartbeatCount,
+		increment:        heartbeatTimeout / time.Duration(heartbeatCount),
+		comm:             comm,
+		handler:          handler,
+		viewSequence:     viewSequence,
+		selfID:           selfID,
+		signer:           signer,
+		verifier:         verifier,
+		quorumSize:       quorumSize,
+		weights:          weights,
+		adaptive:         adaptive,
+		resolver:         resolver,
+		precision:        precision,
+		msgDelay:         msgDelay,
+		timestamps:       make(map[uint64][]timestampSample),
+		pendingSends:     make(map[uint64]time.Time),
+		rtt:              make(map[uint64]*rttEstimate),
+		stopChan:         make(chan struct{}),
+	}
+
+	go hm.run()
+
+	return hm
+}
+
+// Close stops the monitor's background goroutine.
+func (hm *HeartbeatMonitor) Close() {
+	hm.closeOnce.Do(func() {
+		close(hm.stopChan)
+	})
+}
+
+// ChangeRole tells the monitor which role this node now has, for which view, and who the leader
+// of that view is (itself, if role is Leader).
+func (hm *HeartbeatMonitor) ChangeRole(role Role, view uint64, leaderID uint64) {
+	hm.mutex.Lock()
+	hm.role = role
+	hm.view = view
+	hm.leaderID = leaderID
+	hm.toCount = 0
+	hm.timedOut = false
+	hm.lastNonce = 0
+	hm.ackedFollowers = nil
+	hm.missedQuorumWindows = 0
+	hm.quorumLossSignaled = false
+	if role == Leader {
+		// Default to true, matching what refreshVoterStatus would itself default to with a nil
+		// resolver, so a tick landing before refreshVoterStatus's round trip completes below still
+		// heartbeats rather than spuriously suppressing a legitimate leader's very first window.
+		hm.isVoter = true
+	}
+	hm.mutex.Unlock()
+
+	// isVoter only gates the leader's own broadcast path, so there's no need to pay for a
+	// resolver round trip on the N-1 nodes that just became followers.
+	if role != Leader {
+		return
+	}
+	hm.refreshVoterStatus(view)
+}
+
+// HandleMembershipChange lets the consensus engine notify the monitor synchronously, the moment a
+// reconfiguration transaction commits, that the node's voter status for view may have changed.
+// Without this, a node reconfigured out of the voter set would keep broadcasting heartbeats for
+// the rest of the current view, since ChangeRole (the only other place voter status is refreshed)
+// isn't called again until the next view change.
+func (hm *HeartbeatMonitor) HandleMembershipChange(view uint64) {
+	hm.mutex.Lock()
+	relevant := hm.role == Leader && hm.view == view
+	hm.mutex.Unlock()
+
+	if !relevant {
+		return
+	}
+	hm.refreshVoterStatus(view)
+}
+
+// refreshVoterStatus consults resolver for view, outside of hm.mutex since resolver may do
+// non-trivial work, and then stores the result if the monitor is still leading view by the time
+// the call returns.
+func (hm *HeartbeatMonitor) refreshVoterStatus(view uint64) {
+	voter := true
+	if hm.resolver != nil {
+		voter = hm.resolver.IsVoter(hm.selfID, view)
+	}
+
+	hm.mutex.Lock()
+	if hm.role == Leader && hm.view == view {
+		hm.isVoter = voter
+	}
+	hm.mutex.Unlock()
+}
+
+func (hm *HeartbeatMonitor) run() {
+	for {
+		select {
+		case <-hm.stopChan:
+			return
+		case now := <-hm.scheduler:
+			hm.handleTick(now)
+		}
+	}
+}
+
+func (hm *HeartbeatMonitor) handleTick(now time.Time) {
+	hm.mutex.Lock()
+	role := hm.role
+	hm.mutex.Unlock()
+
+	switch role {
+	case Leader:
+		hm.maybeSendHeartbeat(now)
+	case Follower:
+		hm.tickFollowerTimeout()
+	}
+}
+
+func (hm *HeartbeatMonitor) viewSequenceSnapshot() ViewSequence {
+	if vs, ok := hm.viewSequence.Load().(ViewSequence); ok {
+		return vs
+	}
+	return ViewSequence{}
+}
+
+func (hm *HeartbeatMonitor) maybeSendHeartbeat(now time.Time) {
+	hm.mutex.Lock()
+	if hm.lastSent.IsZero() {
+		hm.lastSent = now
+		hm.mutex.Unlock()
+		return
+	}
+	if now.Sub(hm.lastSent) < hm.increment {
+		hm.mutex.Unlock()
+		return
+	}
+	hm.lastSent = now
+	view, leaderID := hm.view, hm.leaderID
+	voter := hm.isVoter
+	hm.nonce++
+	nonce := hm.nonce
+	quorumLost := hm.closeOutAckWindow()
+	hm.increment = hm.effectiveTimeoutLocked() / time.Duration(hm.heartbeatCount)
+	hm.mutex.Unlock()
+
+	if quorumLost {
+		hm.handler.OnQuorumLoss(view)
+	}
+
+	vs := hm.viewSequenceSnapshot()
+	if !vs.ViewActive {
+		return
+	}
+
+	if !voter {
+		hm.log.Debugf("Not a voter in view %d, suppressing heartbeat broadcast", view)
+		return
+	}
+
+	hm.recordTimestamp(hm.selfID, view, vs.ProposalSeq, now)
+	hm.sendHeartbeat(view, vs.ProposalSeq, leaderID, nonce, now)
+}
+
+// closeOutAckWindow tallies the acks received for the window that is ending, resets the tally for
+// the window that's about to start, and reports whether quorum has now been missed for
+// heartbeatCount consecutive windows. Must be called with hm.mutex held.
+func (hm *HeartbeatMonitor) closeOutAckWindow() bool {
+	acked := uint64(len(hm.ackedFollowers)) + 1 // +1: the leader is alive by definition
+	hm.ackedFollowers = make(map[uint64]bool)
+
+	if acked < hm.quorumSize {
+		hm.missedQuorumWindows++
+	} else {
+		hm.missedQuorumWindows = 0
+	}
+
+	if hm.missedQuorumWindows < hm.heartbeatCount || hm.quorumLossSignaled {
+		return false
+	}
+	hm.quorumLossSignaled = true
+	return true
+}
+
+// effectiveTimeoutLocked returns the heartbeat timeout currently in effect. With AdaptiveHeartbeat
+// off, or before any RTT sample has been observed, that's simply the configured
+// heartbeatTimeout. Otherwise it's the worst (largest) per-follower srtt+4*rttvar, floored at
+// minAdaptiveHeartbeatTimeout so a cluster of suspiciously fast samples can't shrink it to
+// something that trips on ordinary scheduling noise. Must be called with hm.mutex held.
+func (hm *HeartbeatMonitor) effectiveTimeoutLocked() time.Duration {
+	if !hm.adaptive || len(hm.rtt) == 0 {
+		return hm.heartbeatTimeout
+	}
+
+	var worst time.Duration
+	for _, e := range hm.rtt {
+		if t := e.timeout(); t > worst {
+			worst = t
+		}
+	}
+	if worst < minAdaptiveHeartbeatTimeout {
+		return minAdaptiveHeartbeatTimeout
+	}
+	return worst
+}
+
+// Stats returns a snapshot of the monitor's current adaptive state, for observability.
+func (hm *HeartbeatMonitor) Stats() HeartbeatStats {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	rtt := make(map[uint64]RTTStats, len(hm.rtt))
+	for nodeID, e := range hm.rtt {
+		rtt[nodeID] = RTTStats{SRTT: e.srtt, RTTVar: e.rttvar}
+	}
+
+	return HeartbeatStats{
+		Adaptive:         hm.adaptive,
+		EffectiveTimeout: hm.effectiveTimeoutLocked(),
+		Interval:         hm.increment,
+		RTT:              rtt,
+	}
+}
+
+func (hm *HeartbeatMonitor) sendHeartbeat(view, seq, leaderID, nonce uint64, now time.Time) {
+	hb := &smartbftprotos.HeartBeat{
+		View:              view,
+		Seq:               seq,
+		Nonce:             nonce,
+		TimestampUnixNano: now.UnixNano(),
+	}
+
+	sentAt := time.Now()
+	hm.mutex.Lock()
+	hm.pendingSends[nonce] = sentAt
+	for n := range hm.pendingSends {
+		if n+pendingSendHistory < nonce {
+			delete(hm.pendingSends, n)
+		}
+	}
+	hm.mutex.Unlock()
+
+	if hm.signer != nil {
+		// The digest is signed over the canonical identity, not hm.signer.Identity()'s raw bytes,
+		// because verifyHeartbeat always canonicalizes the identity it received before recomputing
+		// the digest: signing over the raw form would make verification fail on any follower
+		// whenever SanitizeIdentity actually changes the bytes (e.g. a differently-PEM-wrapped but
+		// equivalent certificate).
+		canonicalIdentity, _, err := SanitizeIdentity(hm.signer.Identity(), nil)
+		if err != nil {
+			hm.log.Warnf("Failed sanitizing own identity for view %d: %v", view, err)
+		} else {
+			digest, err := heartbeatDigest(view, seq, leaderID, nonce, canonicalIdentity)
+			if err != nil {
+				hm.log.Warnf("Failed computing heartbeat digest for view %d: %v", view, err)
+			} else {
+				signature := hm.signer.Sign(digest)
+				_, canonicalSignature, err := SanitizeIdentity(hm.signer.Identity(), signature)
+				if err != nil {
+					hm.log.Warnf("Failed sanitizing own heartbeat signature for view %d: %v", view, err)
+				} else {
+					hb.Identity = canonicalIdentity
+					hb.Signature = canonicalSignature
+				}
+			}
+		}
+	}
+
+	hm.comm.BroadcastConsensus(&smartbftprotos.Message{
+		Content: &smartbftprotos.Message_HeartBeat{HeartBeat: hb},
+	})
+}
+
+func (hm *HeartbeatMonitor) tickFollowerTimeout() {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	hm.toCount++
+	if hm.timedOut || hm.toCount < hm.heartbeatCount {
+		return
+	}
+
+	hm.timedOut = true
+	view, leaderID := hm.view, hm.leaderID
+	hm.handler.OnHeartbeatTimeout(view, leaderID)
+}
+
+// ProcessMsg is called whenever a message is received from sender. HeartBeatResponse messages are
+// handed to processHeartBeatResponse. Of HeartBeat messages, only those from the currently trusted
+// leader, for the current view, are acted upon; everything else is ignored.
+func (hm *HeartbeatMonitor) ProcessMsg(sender uint64, msg *smartbftprotos.Message) {
+	if resp := msg.GetHeartBeatResponse(); resp != nil {
+		hm.processHeartBeatResponse(sender, resp)
+		return
+	}
+
+	hb := msg.GetHeartBeat()
+	if hb == nil {
+		return
+	}
+
+	hm.mutex.Lock()
+	role, view, leaderID, lastNonce := hm.role, hm.view, hm.leaderID, hm.lastNonce
+	hm.mutex.Unlock()
+
+	if role != Follower || sender != leaderID || hb.GetView() != view {
+		return
+	}
+
+	vs := hm.viewSequenceSnapshot()
+	if vs.ViewActive && hb.GetSeq() > vs.ProposalSeq+1 {
+		hm.log.Warnf("Heartbeat from leader %d is for seq %d but we are only at seq %d in view %d, ignoring it",
+			sender, hb.GetSeq(), vs.ProposalSeq, view)
+		return
+	}
+
+	if hb.GetNonce() <= lastNonce {
+		hm.log.Warnf("Heartbeat from leader %d carries nonce %d which is not greater than the last accepted nonce %d, dropping it",
+			sender, hb.GetNonce(), lastNonce)
+		return
+	}
+
+	if err := hm.verifyHeartbeat(sender, hb); err != nil {
+		hm.log.Warnf("Failed verifying heartbeat from %d: %v", sender, err)
+		return
+	}
+
+	hm.mutex.Lock()
+	hm.toCount = 0
+	hm.timedOut = false
+	if hb.GetNonce() > hm.lastNonce {
+		hm.lastNonce = hb.GetNonce()
+	}
+	hm.mutex.Unlock()
+
+	ackTime := time.Now()
+	hm.comm.SendConsensus(leaderID, &smartbftprotos.Message{
+		Content: &smartbftprotos.Message_HeartBeatResponse{
+			HeartBeatResponse: &smartbftprotos.HeartBeatResponse{
+				View:              view,
+				Seq:               hb.GetSeq(),
+				LastCommittedSeq:  vs.ProposalSeq,
+				TimestampUnixNano: ackTime.UnixNano(),
+				Nonce:             hb.GetNonce(),
+			},
+		},
+	})
+}
+
+// processHeartBeatResponse records that sender is alive and caught up, for the purpose of the
+// leader's own quorum-of-acks tracking, records sender's own clock reading for MedianTimestamp,
+// and, if the response's nonce matches a heartbeat we're still tracking, folds the elapsed
+// round-trip time into sender's RTT estimate. Responses for a view other than the one we're
+// currently leading are stale and ignored.
+func (hm *HeartbeatMonitor) processHeartBeatResponse(sender uint64, resp *smartbftprotos.HeartBeatResponse) {
+	receivedAt := time.Now()
+
+	hm.mutex.Lock()
+	if hm.role != Leader || resp.GetView() != hm.view {
+		hm.mutex.Unlock()
+		return
+	}
+
+	if hm.ackedFollowers == nil {
+		hm.ackedFollowers = make(map[uint64]bool)
+	}
+	hm.ackedFollowers[sender] = true
+
+	if sentAt, ok := hm.pendingSends[resp.GetNonce()]; ok {
+		e, ok := hm.rtt[sender]
+		if !ok {
+			e = &rttEstimate{}
+			hm.rtt[sender] = e
+		}
+		e.update(receivedAt.Sub(sentAt))
+	}
+	hm.mutex.Unlock()
+
+	hm.recordTimestamp(sender, resp.GetView(), resp.GetSeq(), time.Unix(0, resp.GetTimestampUnixNano()))
+}
+
+func (hm *HeartbeatMonitor) verifyHeartbeat(sender uint64, hb *smartbftprotos.HeartBeat) error {
+	canonicalIdentity, canonicalSignature, err := SanitizeIdentity(hb.GetIdentity(), hb.GetSignature())
+	if err != nil {
+		return fmt.Errorf("sanitizing identity: %w", err)
+	}
+
+	digest, err := heartbeatDigest(hb.GetView(), hb.GetSeq(), sender, hb.GetNonce(), canonicalIdentity)
+	if err != nil {
+		return fmt.Errorf("computing digest: %w", err)
+	}
+
+	return hm.verifier.VerifySignature(canonicalIdentity, digest, canonicalSignature)
+}
+
+// recordTimestamp appends sample (view, seq, timestamp) to nodeID's ring buffer, evicting the
+// oldest entry once the buffer is full.
+func (hm *HeartbeatMonitor) recordTimestamp(nodeID, view, seq uint64, timestamp time.Time) {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+
+	ring := hm.timestamps[nodeID]
+	ring = append(ring, timestampSample{view: view, seq: seq, timestamp: timestamp})
+	if len(ring) > timestampRingSize {
+		ring = ring[len(ring)-timestampRingSize:]
+	}
+	hm.timestamps[nodeID] = ring
+}
+
+// weightOf returns nodeID's voting weight, defaulting to 1 for nodes with no configured weight.
+// Must be called with hm.mutex held.
+func (hm *HeartbeatMonitor) weightOf(nodeID uint64) uint64 {
+	if w, ok := hm.weights[nodeID]; ok {
+		return w
+	}
+	return 1
+}
+
+// MedianTimestamp returns the stake-weighted median of each node's most recent timestamp sample,
+// counting a node only if that sample is for view and its timestamp is no older than
+// heartbeatTimeout, and whether any such sample exists. It gives the consensus engine a Byzantine-fault-tolerant clock without relying on any
+// single node's (possibly skewed or malicious) local time.
+func (hm *HeartbeatMonitor) MedianTimestamp(view uint64) (time.Time, bool) {
+	type candidate struct {
+		nodeID    uint64
+		timestamp time.Time
+		weight    uint64
+	}
+
+	hm.mutex.Lock()
+	now := time.Now()
+	var candidates []candidate
+	for nodeID, ring := range hm.timestamps {
+		if len(ring) == 0 {
+			continue
+		}
+		latest := ring[len(ring)-1]
+		if latest.view != view {
+			continue
+		}
+		if now.Sub(latest.timestamp) > hm.heartbeatTimeout {
+			continue
+		}
+		candidates = append(candidates, candidate{nodeID: nodeID, timestamp: latest.timestamp, weight: hm.weightOf(nodeID)})
+	}
+	hm.mutex.Unlock()
+
+	if len(candidates) == 0 {
+		return time.Time{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].timestamp.Equal(candidates[j].timestamp) {
+			return candidates[i].timestamp.Before(candidates[j].timestamp)
+		}
+		return candidates[i].nodeID < candidates[j].nodeID
+	})
+
+	var totalWeight uint64
+	for _, c := range candidates {
+		totalWeight += c.weight
+	}
+
+	var cumulative uint64
+	for _, c := range candidates {
+		cumulative += c.weight
+		if cumulative*2 > totalWeight {
+			return c.timestamp, true
+		}
+	}
+	return candidates[len(candidates)-1].timestamp, true
+}
+
+// ValidateProposalTimestamp reports whether timestamp is acceptable for a proposal in view, given
+// prevTimestamp, the timestamp of the previous committed block. It enforces three independent
+// bounds: timestamp must be strictly greater than prevTimestamp; it must be within hm.precision of
+// this node's own local clock; and it must be within hm.msgDelay of the current MedianTimestamp for
+// view. The last check fails closed: with no median sample available yet, the proposal is rejected
+// rather than accepted on trust.
+//
+// Nothing in this package calls ValidateProposalTimestamp yet — there is no proposal message or
+// ingestion path in this repository for it to guard. It exists so that whichever component comes to
+// own proposal validation can drive BFT-Time off of it directly instead of reimplementing the
+// policy.
+func (hm *HeartbeatMonitor) ValidateProposalTimestamp(view uint64, timestamp, prevTimestamp time.Time) bool {
+	if !timestamp.After(prevTimestamp) {
+		return false
+	}
+
+	now := time.Now()
+	if timestamp.Before(now.Add(-hm.precision)) || timestamp.After(now.Add(hm.precision)) {
+		return false
+	}
+
+	median, ok := hm.MedianTimestamp(view)
+	if !ok {
+		return false
+	}
+	if timestamp.Before(median.Add(-hm.msgDelay)) || timestamp.After(median.Add(hm.msgDelay)) {
+		return false
+	}
+
+	return true
+}
+
+// heartbeatDigest is the payload a heartbeat's Signature attests to. Binding sender (the
+// transport-level sender ID, not anything carried inside the message) prevents a valid signature
+// from being replayed under a different claimed sender.
+func heartbeatDigest(view, seq, sender, nonce uint64, identity []byte) ([]byte, error) {
+	h := sha256.New()
+	var buf [8]byte
+	for _, v := range []uint64{view, seq, sender, nonce} {
+		binary.BigEndian.PutUint64(buf[:], v)
+		if _, err := h.Write(buf[:]); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := h.Write(identity); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -6,6 +6,16 @@
 package bft_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -15,35 +25,157 @@ import (
 	"github.com/SmartBFT-Go/consensus/internal/bft/mocks"
 	"github.com/SmartBFT-Go/consensus/pkg/consensus"
 	"github.com/SmartBFT-Go/consensus/smartbftprotos"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
+// fakeIdentity is a throwaway self-signed ECDSA identity shared by fakeSigner and fakeVerifier, so
+// tests exercise the real SanitizeIdentity/signature-verification path heartbeats go through in
+// production instead of stubbing authentication out entirely.
+var fakeIdentity = newFakeIdentity()
+
+type fakeIdentityMaterial struct {
+	key               *ecdsa.PrivateKey
+	marshaledIdentity []byte
+}
+
+func newFakeIdentity() *fakeIdentityMaterial {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "heartbeatmonitor_test"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+
+	identity, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   "test-msp",
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &fakeIdentityMaterial{key: key, marshaledIdentity: identity}
+}
+
+// fakeSigner and fakeVerifier sign and verify using fakeIdentity, giving tests a minimal but real
+// crypto pair instead of a permissive stub.
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(digest []byte) []byte {
+	sig, err := ecdsa.SignASN1(rand.Reader, fakeIdentity.key, digest)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func (fakeSigner) Identity() []byte { return fakeIdentity.marshaledIdentity }
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) VerifySignature(identity, digest, signature []byte) error {
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(identity, sID); err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return errors.New("no PEM block found in identity")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("identity does not carry an ECDSA public key")
+	}
+
+	if !ecdsa.VerifyASN1(pub, digest, signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
 const (
 	heartbeatTimeout = 60 * time.Second
 	heartbeatCount   = 10
 )
 
-var (
-	heartbeat = &smartbftprotos.Message{
-		Content: &smartbftprotos.Message_HeartBeat{
-			HeartBeat: &smartbftprotos.HeartBeat{
-				View: 10,
-				Seq:  10,
-			},
-		},
+// nonCanonicalIdentity re-wraps fakeIdentity's certificate in a PEM block carrying an extra header,
+// giving it a different byte encoding than fakeIdentity.marshaledIdentity even though it's the same
+// certificate and SanitizeIdentity would canonicalize both down to the same bytes.
+func nonCanonicalIdentity() []byte {
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(fakeIdentity.marshaledIdentity, sID); err != nil {
+		panic(err)
+	}
+	block, _ := pem.Decode(sID.IdBytes)
+
+	reencoded := pem.EncodeToMemory(&pem.Block{
+		Type:    block.Type,
+		Headers: map[string]string{"X-Reencoded": "true"},
+		Bytes:   block.Bytes,
+	})
+
+	identity, err := proto.Marshal(&msp.SerializedIdentity{Mspid: sID.Mspid, IdBytes: reencoded})
+	if err != nil {
+		panic(err)
+	}
+	return identity
+}
+
+// nonCanonicalSigner signs with fakeIdentity's key but reports its identity in a non-canonical
+// encoding, exercising the case where SanitizeIdentity actually changes the identity bytes.
+type nonCanonicalSigner struct{}
+
+func (nonCanonicalSigner) Sign(digest []byte) []byte { return fakeSigner{}.Sign(digest) }
+func (nonCanonicalSigner) Identity() []byte          { return nonCanonicalIdentity() }
+
+// testHeartbeatDigest mirrors heartbeatDigest in heartbeatmonitor.go, letting tests pre-sign a
+// heartbeat for the exact (view, seq, sender, nonce, identity) tuple the real verification path
+// will hash.
+func testHeartbeatDigest(view, seq, sender, nonce uint64, identity []byte) []byte {
+	h := sha256.New()
+	var buf [8]byte
+	for _, v := range []uint64{view, seq, sender, nonce} {
+		binary.BigEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
 	}
+	h.Write(identity)
+	return h.Sum(nil)
+}
 
-	heartbeatFromFarAheadLeader = &smartbftprotos.Message{
+// newSignedHeartbeat builds a HeartBeat message carrying a valid signature over
+// (view, seq, sender, nonce) from fakeIdentity, as ProcessMsg requires of every heartbeat.
+func newSignedHeartbeat(view, seq, sender, nonce uint64) *smartbftprotos.Message {
+	digest := testHeartbeatDigest(view, seq, sender, nonce, fakeIdentity.marshaledIdentity)
+	return &smartbftprotos.Message{
 		Content: &smartbftprotos.Message_HeartBeat{
 			HeartBeat: &smartbftprotos.HeartBeat{
-				View: 10,
-				Seq:  15,
+				View:      view,
+				Seq:       seq,
+				Identity:  fakeIdentity.marshaledIdentity,
+				Signature: fakeSigner{}.Sign(digest),
+				Nonce:     nonce,
 			},
 		},
 	}
-)
+}
 
 func TestHeartbeatMonitor_New(t *testing.T) {
 	basicLog, err := zap.NewDevelopment()
@@ -54,7 +186,7 @@ func TestHeartbeatMonitor_New(t *testing.T) {
 	handler := &mocks.HeartbeatTimeoutHandler{}
 
 	scheduler := make(chan time.Time)
-	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, &atomic.Value{})
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, &atomic.Value{}, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
 	assert.NotNil(t, hm)
 	hm.Close()
 }
@@ -70,7 +202,7 @@ func TestHeartbeatMonitorLeader(t *testing.T) {
 
 	vs := &atomic.Value{}
 	vs.Store(bft.ViewSequence{ViewActive: true})
-	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs)
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
 
 	var heartBeatsSent uint32
 	var heartBeatsSentUntilViewBecomesInactive uint32
@@ -113,6 +245,87 @@ func TestHeartbeatMonitorLeader(t *testing.T) {
 	hm.Close()
 }
 
+func TestHeartbeatMonitorLeaderQuorumLoss(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	comm := &mocks.CommMock{}
+	comm.On("BroadcastConsensus", mock.AnythingOfType("*smartbftprotos.Message")).Return()
+
+	handler := &mocks.HeartbeatTimeoutHandler{}
+	toWG := &sync.WaitGroup{}
+	toWG.Add(1)
+	handler.On("OnQuorumLoss", uint64(10)).Run(func(args mock.Arguments) {
+		toWG.Done()
+	}).Return()
+
+	scheduler := make(chan time.Time)
+	vs := &atomic.Value{}
+	vs.Store(bft.ViewSequence{ViewActive: true})
+
+	// quorumSize of 2 can never be reached: nothing ever calls ProcessMsg with a
+	// HeartBeatResponse, so the leader is always alone.
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 2, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+
+	clock := fakeTime{}
+	hm.ChangeRole(bft.Leader, 10, 1)
+	clock.advanceTime(heartbeatCount+1, scheduler)
+	toWG.Wait()
+	hm.Close()
+
+	handler.AssertNumberOfCalls(t, "OnQuorumLoss", 1)
+}
+
+func TestHeartbeatMonitorLeaderSuppressedWhenNotVoter(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	comm := &mocks.CommMock{}
+	handler := &mocks.HeartbeatTimeoutHandler{}
+	scheduler := make(chan time.Time)
+
+	vs := &atomic.Value{}
+	vs.Store(bft.ViewSequence{ViewActive: true})
+
+	resolver := &mocks.MembershipResolver{}
+	var isVoter atomic.Value
+	isVoter.Store(true)
+	resolver.On("IsVoter", uint64(1), mock.AnythingOfType("uint64")).Return(func(_, _ uint64) bool {
+		return isVoter.Load().(bool)
+	})
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, resolver, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	var heartBeatsSent uint32
+	var toWG sync.WaitGroup
+	toWG.Add(5)
+	comm.On("BroadcastConsensus", mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
+		atomic.AddUint32(&heartBeatsSent, 1)
+		toWG.Done()
+	}).Return()
+
+	clock := fakeTime{}
+	hm.ChangeRole(bft.Leader, 10, 1)
+	clock.advanceTime(6, scheduler)
+	toWG.Wait()
+
+	sentBeforeSuppression := atomic.LoadUint32(&heartBeatsSent)
+	assert.Positive(t, sentBeforeSuppression, "leader should have been broadcasting heartbeats")
+
+	// Reconfiguration commits mid-view: this node is no longer a voter. HandleMembershipChange
+	// lets the monitor pick that up immediately rather than waiting out the racy window until the
+	// next ChangeRole.
+	isVoter.Store(false)
+	hm.HandleMembershipChange(10)
+
+	clock.advanceTime(6, scheduler)
+	assert.Equal(t, sentBeforeSuppression, atomic.LoadUint32(&heartBeatsSent),
+		"no further heartbeats should be broadcast once this node is no longer a voter")
+}
+
 func TestHeartbeatMonitorFollower(t *testing.T) {
 	noop := func(_ *bft.HeartbeatMonitor) {}
 
@@ -120,6 +333,9 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 		description                 string
 		onHeartbeatTimeoutCallCount int
 		heartbeatMessage            *smartbftprotos.Message
+		signHeartbeat               bool
+		heartbeatView               uint64
+		heartbeatSeq                uint64
 		event                       func(*bft.HeartbeatMonitor)
 		sender                      uint64
 		viewActive                  bool
@@ -133,16 +349,20 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 			event:                       noop,
 		},
 		{
-			description:      "heartbeats prevent timeout",
-			sender:           12,
-			heartbeatMessage: heartbeat,
-			event:            noop,
+			description:   "heartbeats prevent timeout",
+			sender:        12,
+			signHeartbeat: true,
+			heartbeatView: 10,
+			heartbeatSeq:  10,
+			event:         noop,
 		},
 		{
-			description:      "heartbeats from leader with inactive view don't prevent timeout",
-			sender:           12,
-			heartbeatMessage: heartbeat,
-			event:            noop,
+			description:   "heartbeats from leader with inactive view prevent timeout",
+			sender:        12,
+			signHeartbeat: true,
+			heartbeatView: 10,
+			heartbeatSeq:  10,
+			event:         noop,
 		},
 		{
 			description:                 "bad heartbeats do not prevent timeout",
@@ -154,14 +374,18 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 		{
 			description:                 "heartbeats not from the leader do not prevent timeout",
 			sender:                      13,
-			heartbeatMessage:            heartbeat,
+			signHeartbeat:               true,
+			heartbeatView:               10,
+			heartbeatSeq:                10,
 			onHeartbeatTimeoutCallCount: 1,
 			event:                       noop,
 		},
 		{
 			description:                 "heartbeats from a leader too far ahead lead to timeout",
 			sender:                      12,
-			heartbeatMessage:            heartbeatFromFarAheadLeader,
+			signHeartbeat:               true,
+			heartbeatView:               10,
+			heartbeatSeq:                15,
 			onHeartbeatTimeoutCallCount: 1,
 			event:                       noop,
 			proposalSeqInView:           10,
@@ -170,7 +394,9 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 		{
 			description:       "heartbeats from a leader only 1 seq ahead do not lead to timeout",
 			sender:            12,
-			heartbeatMessage:  heartbeatFromFarAheadLeader,
+			signHeartbeat:     true,
+			heartbeatView:     10,
+			heartbeatSeq:      15,
 			event:             noop,
 			proposalSeqInView: 14,
 			viewActive:        true,
@@ -178,7 +404,9 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 		{
 			description:                 "heartbeats from a leader too far ahead when view is disabled do not cause timeouts",
 			sender:                      12,
-			heartbeatMessage:            heartbeatFromFarAheadLeader,
+			signHeartbeat:               true,
+			heartbeatView:               10,
+			heartbeatSeq:                15,
 			onHeartbeatTimeoutCallCount: 0,
 			event:                       noop,
 			proposalSeqInView:           10,
@@ -201,6 +429,7 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 			incrementUnit := heartbeatTimeout / heartbeatCount
 
 			comm := &mocks.CommMock{}
+			comm.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Return()
 			handler := &mocks.HeartbeatTimeoutHandler{}
 			handler.On("OnHeartbeatTimeout", uint64(10), uint64(12))
 			handler.On("OnHeartbeatTimeout", uint64(11), uint64(12))
@@ -210,13 +439,21 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 				ViewActive:  testCase.viewActive,
 				ProposalSeq: testCase.proposalSeqInView,
 			})
-			hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, viewSequence)
+			hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, viewSequence, 12, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
 
 			hm.ChangeRole(bft.Follower, 10, 12)
 
+			// nextNonce produces a strictly increasing nonce across the priming heartbeat below and
+			// every loop iteration, since ProcessMsg now rejects a replayed nonce unconditionally.
+			var nonce uint64
+			nextNonce := func() uint64 {
+				nonce++
+				return nonce
+			}
+
 			start := time.Now()
 			scheduler <- start
-			hm.ProcessMsg(12, heartbeat)
+			hm.ProcessMsg(12, newSignedHeartbeat(10, 10, 12, nextNonce()))
 			testCase.event(hm)
 
 			start = start.Add(incrementUnit).Add(time.Second)
@@ -224,7 +461,12 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 			for i := time.Duration(1); i <= heartbeatCount*2; i++ {
 				elapsed := start.Add(incrementUnit*i + time.Millisecond)
 				scheduler <- elapsed
-				hm.ProcessMsg(testCase.sender, testCase.heartbeatMessage)
+
+				msg := testCase.heartbeatMessage
+				if testCase.signHeartbeat {
+					msg = newSignedHeartbeat(testCase.heartbeatView, testCase.heartbeatSeq, 12, nextNonce())
+				}
+				hm.ProcessMsg(testCase.sender, msg)
 			}
 			hm.Close()
 
@@ -233,6 +475,265 @@ func TestHeartbeatMonitorFollower(t *testing.T) {
 	}
 }
 
+func TestHeartbeatMonitorVerifiesSignature(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	scheduler := make(chan time.Time)
+	comm := &mocks.CommMock{}
+	var acked uint32
+	comm.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Run(func(_ mock.Arguments) {
+		atomic.AddUint32(&acked, 1)
+	}).Return()
+	handler := &mocks.HeartbeatTimeoutHandler{}
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, &atomic.Value{}, 12, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	hm.ChangeRole(bft.Follower, 10, 12)
+
+	good := newSignedHeartbeat(10, 1, 12, 1)
+	hm.ProcessMsg(12, good)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&acked), "a correctly signed heartbeat should be acked")
+
+	tampered := newSignedHeartbeat(10, 2, 12, 2)
+	tampered.GetHeartBeat().Signature[0] ^= 0xFF
+	hm.ProcessMsg(12, tampered)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&acked), "a heartbeat with a bad signature must not be acked")
+}
+
+func TestHeartbeatMonitorRejectsReplayedNonce(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	scheduler := make(chan time.Time)
+	comm := &mocks.CommMock{}
+	var acked uint32
+	comm.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Run(func(_ mock.Arguments) {
+		atomic.AddUint32(&acked, 1)
+	}).Return()
+	handler := &mocks.HeartbeatTimeoutHandler{}
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, &atomic.Value{}, 12, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	hm.ChangeRole(bft.Follower, 10, 12)
+
+	hm.ProcessMsg(12, newSignedHeartbeat(10, 1, 12, 5))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&acked))
+
+	// Replaying the exact same nonce must be rejected even though the signature is valid.
+	hm.ProcessMsg(12, newSignedHeartbeat(10, 1, 12, 5))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&acked), "a heartbeat replaying a non-increasing nonce must not be acked again")
+
+	// A nonce lower than one already accepted is rejected too.
+	hm.ProcessMsg(12, newSignedHeartbeat(10, 2, 12, 3))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&acked))
+
+	// A fresh, higher nonce is accepted normally.
+	hm.ProcessMsg(12, newSignedHeartbeat(10, 2, 12, 6))
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&acked))
+}
+
+func TestHeartbeatMonitorAcceptsNonCanonicallyEncodedIdentity(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	scheduler1 := make(chan time.Time)
+	scheduler2 := make(chan time.Time)
+
+	comm1 := &mocks.CommMock{}
+	handler1 := &mocks.HeartbeatTimeoutHandler{}
+	vs1 := &atomic.Value{}
+	vs1.Store(bft.ViewSequence{ViewActive: true})
+	// The leader's signer reports its identity in a PEM encoding SanitizeIdentity would re-wrap
+	// differently, even though it's the same certificate fakeVerifier trusts.
+	hm1 := bft.NewHeartbeatMonitor(scheduler1, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm1, handler1, vs1, 1, nonCanonicalSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+
+	comm2 := &mocks.CommMock{}
+	handler2 := &mocks.HeartbeatTimeoutHandler{}
+	vs2 := &atomic.Value{}
+	vs2.Store(bft.ViewSequence{ViewActive: true})
+	hm2 := bft.NewHeartbeatMonitor(scheduler2, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm2, handler2, vs2, 2, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+
+	comm1.On("BroadcastConsensus", mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
+		hm2.ProcessMsg(1, args[0].(*smartbftprotos.Message))
+	})
+	comm2.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Return()
+
+	var timeouts int32
+	handler2.On("OnHeartbeatTimeout", mock.AnythingOfType("uint64"), mock.AnythingOfType("uint64")).Run(func(mock.Arguments) {
+		atomic.AddInt32(&timeouts, 1)
+	}).Return()
+
+	hm1.ChangeRole(bft.Leader, 10, 1)
+	hm2.ChangeRole(bft.Follower, 10, 1)
+
+	clock := fakeTime{}
+	clock.advanceTime(heartbeatCount*2, scheduler1, scheduler2)
+
+	hm1.Close()
+	hm2.Close()
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&timeouts),
+		"a heartbeat signed over the canonical identity must verify even when the signer's own Identity() isn't already in canonical form")
+}
+
+func TestHeartbeatMonitorMedianTimestamp(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	comm := &mocks.CommMock{}
+	handler := &mocks.HeartbeatTimeoutHandler{}
+	scheduler := make(chan time.Time)
+
+	vs := &atomic.Value{}
+	vs.Store(bft.ViewSequence{ViewActive: true})
+
+	weights := map[uint64]uint64{1: 1, 2: 1, 3: 5}
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 1, weights, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	hm.ChangeRole(bft.Leader, 10, 1)
+
+	_, ok := hm.MedianTimestamp(10)
+	assert.False(t, ok, "no samples have been observed yet")
+
+	now := time.Now()
+	hm.ProcessMsg(2, &smartbftprotos.Message{
+		Content: &smartbftprotos.Message_HeartBeatResponse{
+			HeartBeatResponse: &smartbftprotos.HeartBeatResponse{View: 10, Seq: 1, TimestampUnixNano: now.Add(-2 * time.Second).UnixNano()},
+		},
+	})
+	hm.ProcessMsg(3, &smartbftprotos.Message{
+		Content: &smartbftprotos.Message_HeartBeatResponse{
+			HeartBeatResponse: &smartbftprotos.HeartBeatResponse{View: 10, Seq: 1, TimestampUnixNano: now.UnixNano()},
+		},
+	})
+
+	median, ok := hm.MedianTimestamp(10)
+	assert.True(t, ok)
+	// Node 3 alone carries weight 5 out of a total of 6, which exceeds half the total weight, so
+	// its timestamp wins the weighted median over node 2's earlier one.
+	assert.WithinDuration(t, now, median, time.Millisecond)
+
+	_, ok = hm.MedianTimestamp(11)
+	assert.False(t, ok, "samples are scoped to the view they were observed in")
+}
+
+func TestHeartbeatMonitorValidateProposalTimestamp(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	comm := &mocks.CommMock{}
+	handler := &mocks.HeartbeatTimeoutHandler{}
+	scheduler := make(chan time.Time)
+
+	vs := &atomic.Value{}
+	vs.Store(bft.ViewSequence{ViewActive: true})
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, 10*time.Second, time.Second)
+	defer hm.Close()
+
+	hm.ChangeRole(bft.Leader, 10, 1)
+
+	prev := time.Now().Add(-time.Minute)
+
+	assert.False(t, hm.ValidateProposalTimestamp(10, prev, prev),
+		"a timestamp must be strictly greater than the previous block's")
+
+	farInFuture := time.Now().Add(time.Hour)
+	assert.False(t, hm.ValidateProposalTimestamp(10, farInFuture, prev),
+		"a timestamp too far from local time must be rejected even with no median sample")
+
+	now := time.Now()
+	hm.ProcessMsg(2, &smartbftprotos.Message{
+		Content: &smartbftprotos.Message_HeartBeatResponse{
+			HeartBeatResponse: &smartbftprotos.HeartBeatResponse{View: 10, Seq: 1, TimestampUnixNano: now.UnixNano()},
+		},
+	})
+
+	assert.True(t, hm.ValidateProposalTimestamp(10, now, prev),
+		"a timestamp close to both local time and the median should validate")
+
+	assert.False(t, hm.ValidateProposalTimestamp(10, now.Add(5*time.Second), prev),
+		"a timestamp too far from the median must be rejected even though it's well within precision of local time")
+}
+
+func TestHeartbeatMonitorAdaptiveTimeout(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	scheduler := make(chan time.Time)
+	comm := &mocks.CommMock{}
+	handler := &mocks.HeartbeatTimeoutHandler{}
+
+	vs := &atomic.Value{}
+	vs.Store(bft.ViewSequence{ViewActive: true})
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, vs, 1, fakeSigner{}, fakeVerifier{}, 1, nil, true, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	const follower = uint64(2)
+	const injectedRTT = 20 * time.Millisecond
+
+	var lastNonce uint64
+	comm.On("BroadcastConsensus", mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
+		msg := args[0].(*smartbftprotos.Message)
+		atomic.StoreUint64(&lastNonce, msg.GetHeartBeat().GetNonce())
+	}).Return()
+
+	hm.ChangeRole(bft.Leader, 10, 1)
+
+	clock := fakeTime{}
+	clock.advanceTimeWithRTT(3, injectedRTT, func(time.Time) {
+		hm.ProcessMsg(follower, &smartbftprotos.Message{
+			Content: &smartbftprotos.Message_HeartBeatResponse{
+				HeartBeatResponse: &smartbftprotos.HeartBeatResponse{
+					View:              10,
+					Nonce:             atomic.LoadUint64(&lastNonce),
+					TimestampUnixNano: time.Now().UnixNano(),
+				},
+			},
+		})
+	}, scheduler)
+
+	stats := hm.Stats()
+	assert.True(t, stats.Adaptive)
+	if assert.Contains(t, stats.RTT, follower) {
+		assert.GreaterOrEqual(t, stats.RTT[follower].SRTT, time.Duration(0))
+	}
+	// A fast, consistently-acking follower should pull both the timeout and the send interval
+	// well below the static LAN defaults.
+	assert.Less(t, stats.EffectiveTimeout, consensus.DefaultConfig.LeaderHeartbeatTimeout)
+	assert.Less(t, stats.Interval, heartbeatTimeout/heartbeatCount)
+}
+
+func TestHeartbeatMonitorAdaptiveOffUsesStaticTimeout(t *testing.T) {
+	basicLog, err := zap.NewDevelopment()
+	assert.NoError(t, err)
+	log := basicLog.Sugar()
+
+	comm := &mocks.CommMock{}
+	handler := &mocks.HeartbeatTimeoutHandler{}
+	scheduler := make(chan time.Time)
+
+	hm := bft.NewHeartbeatMonitor(scheduler, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm, handler, &atomic.Value{}, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
+	defer hm.Close()
+
+	stats := hm.Stats()
+	assert.False(t, stats.Adaptive)
+	assert.Equal(t, consensus.DefaultConfig.LeaderHeartbeatTimeout, stats.EffectiveTimeout)
+	assert.Equal(t, consensus.DefaultConfig.LeaderHeartbeatTimeout/time.Duration(consensus.DefaultConfig.LeaderHeartbeatCount), stats.Interval)
+	assert.Empty(t, stats.RTT)
+}
+
 func TestHeartbeatMonitorLeaderAndFollower(t *testing.T) {
 	basicLog, err := zap.NewDevelopment()
 	assert.NoError(t, err)
@@ -245,13 +746,13 @@ func TestHeartbeatMonitorLeaderAndFollower(t *testing.T) {
 	handler1 := &mocks.HeartbeatTimeoutHandler{}
 	vs1 := &atomic.Value{}
 	vs1.Store(bft.ViewSequence{ViewActive: true})
-	hm1 := bft.NewHeartbeatMonitor(scheduler1, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm1, handler1, vs1)
+	hm1 := bft.NewHeartbeatMonitor(scheduler1, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm1, handler1, vs1, 1, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
 
 	comm2 := &mocks.CommMock{}
 	handler2 := &mocks.HeartbeatTimeoutHandler{}
 	vs2 := &atomic.Value{}
 	vs2.Store(bft.ViewSequence{ViewActive: true})
-	hm2 := bft.NewHeartbeatMonitor(scheduler2, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm2, handler2, vs2)
+	hm2 := bft.NewHeartbeatMonitor(scheduler2, log, consensus.DefaultConfig.LeaderHeartbeatTimeout, consensus.DefaultConfig.LeaderHeartbeatCount, comm2, handler2, vs2, 2, fakeSigner{}, fakeVerifier{}, 1, nil, false, nil, consensus.DefaultConfig.TimestampPrecision, consensus.DefaultConfig.TimestampMaxDelay)
 
 	comm1.On("BroadcastConsensus", mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
 		msg := args[0].(*smartbftprotos.Message)
@@ -263,6 +764,16 @@ func TestHeartbeatMonitorLeaderAndFollower(t *testing.T) {
 		hm1.ProcessMsg(2, msg)
 	})
 
+	// Followers ack every heartbeat they accept straight back to the leader.
+	comm1.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
+		msg := args[1].(*smartbftprotos.Message)
+		hm2.ProcessMsg(1, msg)
+	}).Maybe()
+	comm2.On("SendConsensus", mock.AnythingOfType("uint64"), mock.AnythingOfType("*smartbftprotos.Message")).Run(func(args mock.Arguments) {
+		msg := args[1].(*smartbftprotos.Message)
+		hm1.ProcessMsg(2, msg)
+	}).Maybe()
+
 	toWG := &sync.WaitGroup{}
 	toWG.Add(1)
 	handler1.On("OnHeartbeatTimeout", uint64(12), uint64(2)).Run(func(args mock.Arguments) {
@@ -308,3 +819,19 @@ func (t *fakeTime) advanceTime(ticks time.Duration, schedulers ...chan time.Time
 		t.time = newTime
 	}
 }
+
+// advanceTimeWithRTT behaves like advanceTime, but after delivering each tick it sleeps for rtt
+// (a real, wall-clock delay) and then calls inject, letting a caller simulate a follower ACK
+// landing back at the leader after a synthetic round-trip time.
+func (t *fakeTime) advanceTimeWithRTT(ticks time.Duration, rtt time.Duration, inject func(tick time.Time), schedulers ...chan time.Time) {
+	for i := time.Duration(1); i <= ticks; i++ {
+		incrementUnit := heartbeatTimeout / heartbeatCount
+		newTime := t.time.Add(incrementUnit)
+		for _, scheduler := range schedulers {
+			scheduler <- newTime
+		}
+		time.Sleep(rtt)
+		inject(newTime)
+		t.time = newTime
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bft
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// SanitizeIdentity canonicalizes identity, a marshaled msp.SerializedIdentity, and, when signature
+// is non-empty, normalizes its ECDSA s component to low-S form relative to the order of the curve
+// embedded in the identity's certificate. Both steps make two semantically-equal (identity,
+// signature) pairs hash identically regardless of which crypto backend produced them: without the
+// first, differing PEM encodings of the same certificate would digest differently; without the
+// second, an attacker could flip s on an intercepted heartbeat and resubmit it as a "new" signature
+// that still passes verification, sneaking it past nonce-based replay detection.
+func SanitizeIdentity(identity, signature []byte) (canonicalIdentity, canonicalSignature []byte, err error) {
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(identity, sID); err != nil {
+		return nil, nil, fmt.Errorf("unmarshaling serialized identity: %w", err)
+	}
+
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM block found in identity for MSP %s", sID.Mspid)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing identity certificate: %w", err)
+	}
+
+	canonicalIdentity, err = proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   sID.Mspid,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling canonical identity: %w", err)
+	}
+
+	canonicalSignature = signature
+	if ecdsaKey, ok := cert.PublicKey.(*ecdsa.PublicKey); ok && len(signature) > 0 {
+		if canonicalSignature, err = normalizeECDSASignature(ecdsaKey.Curve, signature); err != nil {
+			return nil, nil, fmt.Errorf("normalizing ECDSA signature: %w", err)
+		}
+	}
+
+	return canonicalIdentity, canonicalSignature, nil
+}
+
+// normalizeECDSASignature reduces the ASN.1 DER encoded sig's s component to the lower half of
+// curve's order if it isn't already there. (r, s) and (r, N-s) both verify against the same
+// message and key, so without this reduction the two are indistinguishable from "different"
+// signatures even though they attest to the exact same thing.
+func normalizeECDSASignature(curve elliptic.Curve, sig []byte) ([]byte, error) {
+	var parsed ecdsaSignature
+	if _, err := asn1.Unmarshal(sig, &parsed); err != nil {
+		return nil, fmt.Errorf("unmarshaling ECDSA signature: %w", err)
+	}
+
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	if parsed.S.Cmp(halfOrder) > 0 {
+		parsed.S = new(big.Int).Sub(curve.Params().N, parsed.S)
+	}
+
+	return asn1.Marshal(parsed)
+}
@@ -0,0 +1,70 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bft_test
+
+import (
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/SmartBFT-Go/consensus/internal/bft"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeIdentityRoundTrip(t *testing.T) {
+	digest := testHeartbeatDigest(10, 1, 12, 1, fakeIdentity.marshaledIdentity)
+	signature := fakeSigner{}.Sign(digest)
+
+	canonicalIdentity, canonicalSignature, err := bft.SanitizeIdentity(fakeIdentity.marshaledIdentity, signature)
+	assert.NoError(t, err)
+	assert.Equal(t, fakeIdentity.marshaledIdentity, canonicalIdentity)
+	assert.NoError(t, fakeVerifier{}.VerifySignature(canonicalIdentity, digest, canonicalSignature))
+}
+
+func TestSanitizeIdentityNormalizesHighS(t *testing.T) {
+	digest := testHeartbeatDigest(10, 1, 12, 1, fakeIdentity.marshaledIdentity)
+	lowS := fakeSigner{}.Sign(digest)
+
+	var parsed struct{ R, S *big.Int }
+	_, err := asn1.Unmarshal(lowS, &parsed)
+	assert.NoError(t, err)
+
+	// Flip S to its high-S complement: (r, s) and (r, N-s) both verify against the same digest and
+	// key, so this is the malleable "different-looking but equivalent" signature SanitizeIdentity
+	// is meant to collapse back to a single canonical form.
+	order := fakeIdentity.key.Curve.Params().N
+	parsed.S = new(big.Int).Sub(order, parsed.S)
+	highS, err := asn1.Marshal(parsed)
+	assert.NoError(t, err)
+
+	_, canonicalFromLowS, err := bft.SanitizeIdentity(fakeIdentity.marshaledIdentity, lowS)
+	assert.NoError(t, err)
+	_, canonicalFromHighS, err := bft.SanitizeIdentity(fakeIdentity.marshaledIdentity, highS)
+	assert.NoError(t, err)
+
+	assert.Equal(t, canonicalFromLowS, canonicalFromHighS,
+		"low-S and high-S encodings of the same signature must canonicalize to identical bytes")
+}
+
+func TestSanitizeIdentityRejectsGarbageIdentity(t *testing.T) {
+	_, _, err := bft.SanitizeIdentity([]byte("not a serialized identity"), nil)
+	assert.Error(t, err)
+}
+
+func TestSanitizeIdentityRejectsEmptyIdentity(t *testing.T) {
+	_, _, err := bft.SanitizeIdentity(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestSanitizeIdentityRejectsMissingPEMBlock(t *testing.T) {
+	identity, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "test-msp", IdBytes: []byte("not pem")})
+	assert.NoError(t, err)
+
+	_, _, err = bft.SanitizeIdentity(identity, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package api defines the interfaces through which the consensus engine is wired to the rest of
+// the host system: logging, networking, signing and verification.
+package api
+
+// Logger is the logging surface the consensus engine and its collaborators are given. It is
+// satisfied by a *zap.SugaredLogger.
+type Logger interface {
+	Debugf(template string, args ...interface{})
+	Infof(template string, args ...interface{})
+	Warnf(template string, args ...interface{})
+	Errorf(template string, args ...interface{})
+	Panicf(template string, args ...interface{})
+}
+
+// Signer signs consensus-internal payloads, such as proposals and heartbeats, on behalf of this
+// node.
+type Signer interface {
+	// Sign signs the given bytes and returns the signature.
+	Sign([]byte) []byte
+	// Identity returns this node's own marshaled identity.
+	Identity() []byte
+}
+
+// Verifier checks signatures produced by a Signer. It is shared by proposal and heartbeat
+// verification so both paths enforce the same trust policy.
+type Verifier interface {
+	// VerifySignature verifies that signature was produced over message by identity.
+	VerifySignature(identity, message, signature []byte) error
+}
@@ -0,0 +1,38 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package consensus
+
+import "time"
+
+// Configuration defines the local configuration of the consensus engine on a single node.
+type Configuration struct {
+	// LeaderHeartbeatTimeout is the maximum time a follower waits without hearing from the leader
+	// before it considers the leader dead and triggers a view change.
+	LeaderHeartbeatTimeout time.Duration
+	// LeaderHeartbeatCount is the number of heartbeats the leader sends over LeaderHeartbeatTimeout.
+	LeaderHeartbeatCount uint64
+	// AdaptiveHeartbeat, when true, has the leader measure per-follower round-trip time from
+	// heartbeat ACKs and derive its heartbeat interval and timeout from that instead of from the
+	// static LeaderHeartbeatTimeout/LeaderHeartbeatCount pair, so a single configuration fits both
+	// low-latency and geo-distributed deployments. When false, LeaderHeartbeatTimeout and
+	// LeaderHeartbeatCount are used verbatim, as before.
+	AdaptiveHeartbeat bool
+	// TimestampPrecision bounds how far a proposal's timestamp may drift from a validating node's
+	// own local clock before HeartbeatMonitor.ValidateProposalTimestamp rejects the proposal.
+	TimestampPrecision time.Duration
+	// TimestampMaxDelay bounds how far a proposal's timestamp may drift from the BFT-Time median
+	// computed by HeartbeatMonitor.MedianTimestamp before ValidateProposalTimestamp rejects it.
+	TimestampMaxDelay time.Duration
+}
+
+// DefaultConfig holds reasonable defaults, suitable for a LAN deployment.
+var DefaultConfig = Configuration{
+	LeaderHeartbeatTimeout: 60 * time.Second,
+	LeaderHeartbeatCount:   10,
+	AdaptiveHeartbeat:      false,
+	TimestampPrecision:     5 * time.Second,
+	TimestampMaxDelay:      2 * time.Second,
+}
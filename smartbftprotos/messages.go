@@ -0,0 +1,176 @@
+// Copyright IBM Corp. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package smartbftprotos holds the wire messages exchanged between consensus nodes, hand-written in
+// the style of protoc-gen-go output (oneof Content fields, Get* accessors) without an accompanying
+// .proto source or code generation step.
+package smartbftprotos
+
+// Message is the envelope carried over the wire between consensus nodes. Exactly one of the
+// Content fields is populated, selected via the oneof below.
+type Message struct {
+	// Types that are valid to be assigned to Content:
+	//	*Message_HeartBeat
+	//	*Message_HeartBeatResponse
+	Content isMessage_Content
+}
+
+type isMessage_Content interface {
+	isMessage_Content()
+}
+
+// Message_HeartBeat wraps a HeartBeat as a Message's Content.
+type Message_HeartBeat struct {
+	HeartBeat *HeartBeat
+}
+
+func (*Message_HeartBeat) isMessage_Content() {}
+
+// Message_HeartBeatResponse wraps a HeartBeatResponse as a Message's Content.
+type Message_HeartBeatResponse struct {
+	HeartBeatResponse *HeartBeatResponse
+}
+
+func (*Message_HeartBeatResponse) isMessage_Content() {}
+
+// GetContent returns the message's oneof content, or nil for a nil Message.
+func (m *Message) GetContent() isMessage_Content {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+// GetHeartBeat returns the HeartBeat carried by this message, or nil if it carries something else.
+func (m *Message) GetHeartBeat() *HeartBeat {
+	if x, ok := m.GetContent().(*Message_HeartBeat); ok {
+		return x.HeartBeat
+	}
+	return nil
+}
+
+// GetHeartBeatResponse returns the HeartBeatResponse carried by this message, or nil if it carries
+// something else.
+func (m *Message) GetHeartBeatResponse() *HeartBeatResponse {
+	if x, ok := m.GetContent().(*Message_HeartBeatResponse); ok {
+		return x.HeartBeatResponse
+	}
+	return nil
+}
+
+// HeartBeat is periodically broadcast by the leader of a view to prove it is still alive. It is
+// authenticated so that a follower never acts on a heartbeat it cannot attribute to the leader it
+// currently trusts.
+type HeartBeat struct {
+	View uint64
+	Seq  uint64
+
+	// Identity is the marshaled identity of the broadcasting leader.
+	Identity []byte
+	// Signature covers (View, Seq, sender ID, Nonce, Identity) and is produced with the leader's
+	// signing key.
+	Signature []byte
+	// Nonce strictly increases on every heartbeat a given leader sends, closing the replay window
+	// a captured-and-resent heartbeat would otherwise open.
+	Nonce uint64
+	// TimestampUnixNano is the leader's local clock reading at the moment it sent this heartbeat,
+	// encoded as nanoseconds since the Unix epoch. It feeds HeartbeatMonitor.MedianTimestamp.
+	TimestampUnixNano int64
+}
+
+func (h *HeartBeat) GetView() uint64 {
+	if h != nil {
+		return h.View
+	}
+	return 0
+}
+
+func (h *HeartBeat) GetSeq() uint64 {
+	if h != nil {
+		return h.Seq
+	}
+	return 0
+}
+
+func (h *HeartBeat) GetIdentity() []byte {
+	if h != nil {
+		return h.Identity
+	}
+	return nil
+}
+
+func (h *HeartBeat) GetSignature() []byte {
+	if h != nil {
+		return h.Signature
+	}
+	return nil
+}
+
+func (h *HeartBeat) GetNonce() uint64 {
+	if h != nil {
+		return h.Nonce
+	}
+	return 0
+}
+
+func (h *HeartBeat) GetTimestampUnixNano() int64 {
+	if h != nil {
+		return h.TimestampUnixNano
+	}
+	return 0
+}
+
+// HeartBeatResponse is sent by a follower directly to the leader, unicast, in reply to every
+// HeartBeat it accepts. It lets the leader measure the liveness of the collective rather than
+// just its own ability to send.
+type HeartBeatResponse struct {
+	View uint64
+	Seq  uint64
+	// LastCommittedSeq is the sequence the follower itself has last committed.
+	LastCommittedSeq uint64
+	// TimestampUnixNano is the follower's own local clock reading at the moment it sent this
+	// response, encoded as nanoseconds since the Unix epoch. Collecting these from every
+	// responding node is what lets the leader derive a BFT-Time median rather than trusting its
+	// own clock alone.
+	TimestampUnixNano int64
+	// Nonce echoes the Nonce of the HeartBeat this response answers, letting the leader match the
+	// response back to the moment it broadcast that heartbeat and so measure round-trip time.
+	Nonce uint64
+}
+
+func (r *HeartBeatResponse) GetView() uint64 {
+	if r != nil {
+		return r.View
+	}
+	return 0
+}
+
+func (r *HeartBeatResponse) GetSeq() uint64 {
+	if r != nil {
+		return r.Seq
+	}
+	return 0
+}
+
+func (r *HeartBeatResponse) GetLastCommittedSeq() uint64 {
+	if r != nil {
+		return r.LastCommittedSeq
+	}
+	return 0
+}
+
+func (r *HeartBeatResponse) GetTimestampUnixNano() int64 {
+	if r != nil {
+		return r.TimestampUnixNano
+	}
+	return 0
+}
+
+func (r *HeartBeatResponse) GetNonce() uint64 {
+	if r != nil {
+		return r.Nonce
+	}
+	return 0
+}